@@ -0,0 +1,32 @@
+// Package codec provides the pluggable serialization formats used by the
+// boltq dump/load subcommands: a single Codec interface with JSON,
+// MessagePack, and gob implementations selected by name at runtime.
+package codec
+
+import "fmt"
+
+// Codec marshals and unmarshals the nested tree produced by the dump
+// subcommand.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+
+	// Ext is the file extension (without a leading dot) conventionally
+	// associated with this codec, e.g. "json".
+	Ext() string
+}
+
+// ByName returns the registered Codec for name ("json", "msgpack", or
+// "gob").
+func ByName(name string) (Codec, error) {
+	switch name {
+	case "json", "":
+		return JSON{}, nil
+	case "msgpack":
+		return MsgPack{}, nil
+	case "gob":
+		return Gob{}, nil
+	default:
+		return nil, fmt.Errorf("codec: unknown codec %q", name)
+	}
+}
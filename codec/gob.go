@@ -0,0 +1,24 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+// Gob is a Go-native codec, useful for round-tripping dumps between boltq
+// instances without worrying about JSON's lossy numeric types.
+type Gob struct{}
+
+func (Gob) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (Gob) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func (Gob) Ext() string { return "gob" }
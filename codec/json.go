@@ -0,0 +1,16 @@
+package codec
+
+import "encoding/json"
+
+// JSON marshals with indentation so dumps are diffable in version control.
+type JSON struct{}
+
+func (JSON) Marshal(v interface{}) ([]byte, error) {
+	return json.MarshalIndent(v, "", "  ")
+}
+
+func (JSON) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (JSON) Ext() string { return "json" }
@@ -0,0 +1,16 @@
+package codec
+
+import "github.com/vmihailenco/msgpack/v5"
+
+// MsgPack is a compact binary alternative to JSON for large dumps.
+type MsgPack struct{}
+
+func (MsgPack) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (MsgPack) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+func (MsgPack) Ext() string { return "msgpack" }
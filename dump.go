@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	"github.com/boltdb/bolt"
+	"github.com/schmichael/boltq/codec"
+	"github.com/schmichael/boltq/model"
+)
+
+// dumpTree mirrors a bucket's contents as a concrete, codec-agnostic value:
+// nested buckets and leaf keys live in separate fields, so a real bucket or
+// key can never collide with the structure boltq uses to describe it (the
+// prior sibling-key convention used a "__meta__" key for this, which could
+// collide with and silently clobber a real bucket/key of that name).
+type dumpTree struct {
+	Buckets map[string]*dumpTree `json:"buckets,omitempty" msgpack:"buckets,omitempty"`
+	Keys    map[string]dumpLeaf  `json:"keys,omitempty" msgpack:"keys,omitempty"`
+}
+
+// dumpLeaf records a single key's value alongside how it was encoded, so
+// load can reverse the encoding exactly rather than guessing from the
+// serialized string. Being a concrete struct (not interface{}) also means
+// gob and msgpack decode it back into the same shape it was encoded from,
+// instead of losing the struct tags that json.Marshal respects.
+type dumpLeaf struct {
+	Encoding string `json:"encoding" msgpack:"encoding"`
+	Value    string `json:"value" msgpack:"value"`
+}
+
+// dumpCmd implements `boltq dump <path> [bucket]`, writing a portable,
+// diffable snapshot of the db (or a bucket subtree) to stdout. -codec is a
+// top-level flag, so it must be given before the "dump" subcommand, e.g.
+// `boltq -codec gob dump mydb.db`.
+func dumpCmd(args []string, sep, codecName string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: boltq [-codec json|msgpack|gob] dump <path> [bucket]")
+	}
+	path := args[0]
+
+	c, err := codec.ByName(codecName)
+	if err != nil {
+		return err
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("error opening db: %w", err)
+	}
+	defer db.Close()
+
+	p := model.NewPath(sep)
+
+	var tree *dumpTree
+	err = db.View(func(tx *bolt.Tx) error {
+		if len(args) >= 2 {
+			bkt := p.Bucket(tx, args[1])
+			if bkt == nil {
+				return fmt.Errorf("bucket %q does not exist", args[1])
+			}
+			tree, err = dumpBucket(tx, bkt)
+			return err
+		}
+		tree, err = dumpBucket(tx, nil)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	out, err := c.Marshal(tree)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(out)
+	return err
+}
+
+// dumpBucket builds a dumpTree mirroring bkt: sub-buckets become nested
+// dumpTrees, keys become dumpLeaf entries recording their encoding and
+// value. bkt may be nil to dump the db's top-level buckets.
+func dumpBucket(tx *bolt.Tx, bkt *bolt.Bucket) (*dumpTree, error) {
+	tree := &dumpTree{}
+
+	for _, e := range model.Children(tx, bkt) {
+		if e.IsBucket {
+			var child *bolt.Bucket
+			if bkt == nil {
+				child = tx.Bucket([]byte(e.Name))
+			} else {
+				child = bkt.Bucket([]byte(e.Name))
+			}
+			sub, err := dumpBucket(tx, child)
+			if err != nil {
+				return nil, err
+			}
+			if tree.Buckets == nil {
+				tree.Buckets = map[string]*dumpTree{}
+			}
+			tree.Buckets[e.Name] = sub
+			continue
+		}
+
+		if tree.Keys == nil {
+			tree.Keys = map[string]dumpLeaf{}
+		}
+		if utf8.Valid(e.Value) {
+			tree.Keys[e.Name] = dumpLeaf{Encoding: "text", Value: string(e.Value)}
+		} else {
+			tree.Keys[e.Name] = dumpLeaf{Encoding: "base64", Value: base64.StdEncoding.EncodeToString(e.Value)}
+		}
+	}
+	return tree, nil
+}
+
+// loadCmd implements `boltq load <path>`, reconstructing a dump produced by
+// dumpCmd in a single db.Update. -codec is a top-level flag, so it must be
+// given before the "load" subcommand, e.g. `boltq -codec gob load mydb.db`.
+// The snapshot is always loaded at the db root: a dump of a bucket subtree
+// recreates that bucket's sub-buckets as top-level buckets, and is rejected
+// if the dumped bucket holds keys directly.
+func loadCmd(args []string, sep, codecName string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: boltq [-codec json|msgpack|gob] load <path>")
+	}
+	path := args[0]
+
+	c, err := codec.ByName(codecName)
+	if err != nil {
+		return err
+	}
+
+	in, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("error reading snapshot: %w", err)
+	}
+
+	var tree dumpTree
+	if err := c.Unmarshal(in, &tree); err != nil {
+		return fmt.Errorf("error decoding snapshot: %w", err)
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return fmt.Errorf("error opening db: %w", err)
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		return loadBucket(tx, nil, &tree)
+	})
+}
+
+// loadBucket recreates tree's buckets and keys under bkt, or at the
+// top level of tx when bkt is nil.
+func loadBucket(tx *bolt.Tx, bkt *bolt.Bucket, tree *dumpTree) error {
+	for name, sub := range tree.Buckets {
+		var child *bolt.Bucket
+		var err error
+		if bkt == nil {
+			child, err = tx.CreateBucketIfNotExists([]byte(name))
+		} else {
+			child, err = bkt.CreateBucketIfNotExists([]byte(name))
+		}
+		if err != nil {
+			return err
+		}
+		if err := loadBucket(tx, child, sub); err != nil {
+			return err
+		}
+	}
+
+	for name, leaf := range tree.Keys {
+		if bkt == nil {
+			return fmt.Errorf("key %q found at the db root; keys must live in a bucket", name)
+		}
+		value, err := decodeLeaf(name, leaf)
+		if err != nil {
+			return err
+		}
+		if err := bkt.Put([]byte(name), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeLeaf reverses dumpBucket's encoding of a single leaf value.
+func decodeLeaf(name string, leaf dumpLeaf) ([]byte, error) {
+	if leaf.Encoding == "base64" {
+		v, err := base64.StdEncoding.DecodeString(leaf.Value)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", name, err)
+		}
+		return v, nil
+	}
+	return []byte(leaf.Value), nil
+}
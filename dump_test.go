@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/boltdb/bolt"
+	"github.com/schmichael/boltq/codec"
+)
+
+func openDumpTestDB(t *testing.T) *bolt.DB {
+	t.Helper()
+	f, err := os.CreateTemp("", "boltq-dump-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	t.Cleanup(func() { os.Remove(f.Name()) })
+
+	db, err := bolt.Open(f.Name(), 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// binary is a non-UTF8 value: codecs that route it through a lossy string
+// representation (or forget which codec's field-naming rule applies) will
+// fail to reproduce it exactly.
+var binary = []byte{0xff, 0xfe, 0x00, 0x01, 'h', 'i', 0x80}
+
+func TestDumpBucketRoundTrip(t *testing.T) {
+	for _, name := range []string{"json", "msgpack", "gob"} {
+		t.Run(name, func(t *testing.T) {
+			db := openDumpTestDB(t)
+
+			err := db.Update(func(tx *bolt.Tx) error {
+				b, err := tx.CreateBucket([]byte("users"))
+				if err != nil {
+					return err
+				}
+				if err := b.Put([]byte("alice"), []byte("text value")); err != nil {
+					return err
+				}
+				return b.Put([]byte("avatar"), binary)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var tree *dumpTree
+			err = db.View(func(tx *bolt.Tx) error {
+				tree, err = dumpBucket(tx, nil)
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := codec.ByName(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			out, err := c.Marshal(tree)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+
+			var decoded dumpTree
+			if err := c.Unmarshal(out, &decoded); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+
+			db2 := openDumpTestDB(t)
+			err = db2.Update(func(tx *bolt.Tx) error {
+				return loadBucket(tx, nil, &decoded)
+			})
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+
+			err = db2.View(func(tx *bolt.Tx) error {
+				b := tx.Bucket([]byte("users"))
+				if b == nil {
+					t.Fatal("bucket users missing after load")
+				}
+				if got := b.Get([]byte("alice")); string(got) != "text value" {
+					t.Errorf("alice = %q, want %q", got, "text value")
+				}
+				if got := b.Get([]byte("avatar")); !bytes.Equal(got, binary) {
+					t.Errorf("avatar = %x, want %x", got, binary)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// TestDumpBucketMetaNameCollision ensures a bucket or key literally named
+// like the old "__meta__" sentinel doesn't lose data now that metadata
+// lives in its own struct field rather than a sibling key.
+func TestDumpBucketMetaNameCollision(t *testing.T) {
+	db := openDumpTestDB(t)
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		top, err := tx.CreateBucket([]byte("top"))
+		if err != nil {
+			return err
+		}
+		if err := top.Put([]byte("a"), []byte("1")); err != nil {
+			return err
+		}
+		sub, err := top.CreateBucket([]byte("__meta__"))
+		if err != nil {
+			return err
+		}
+		return sub.Put([]byte("b"), []byte("2"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tree *dumpTree
+	err = db.View(func(tx *bolt.Tx) error {
+		tree, err = dumpBucket(tx, nil)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub, ok := tree.Buckets["top"].Buckets["__meta__"]
+	if !ok {
+		t.Fatal("bucket __meta__ missing from dump")
+	}
+	if got := sub.Keys["b"].Value; got != "2" {
+		t.Errorf("__meta__.b = %q, want %q", got, "2")
+	}
+	if got := tree.Buckets["top"].Keys["a"].Value; got != "1" {
+		t.Errorf("top.a = %q, want %q", got, "1")
+	}
+}
@@ -0,0 +1,248 @@
+// Package index maintains user-declared secondary indexes in sibling
+// "__idx__/<bucket>/<field>" buckets. An index's existence is its own
+// declaration: Update consults whichever "__idx__/<bucket>/<field>"
+// buckets already exist for a given bucket and keeps them in sync as keys
+// are written or removed. Callers pass both the key's old and new value so
+// Update can retract the stale index entry before (or instead of) writing
+// the new one; it must run in the same transaction as the write or delete
+// it follows so the index never observes a torn state.
+package index
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/boltdb/bolt"
+	"github.com/schmichael/boltq/model"
+)
+
+// Root is the top-level bucket under which all indexes live.
+const Root = "__idx__"
+
+// uniqueMarkerKey is written into every index bucket; its value ("1" for a
+// unique index, "0" otherwise) is checked before every write.
+const uniqueMarkerKey = "__unique__"
+
+// bucketPath returns the separator-delimited path to the index bucket for
+// field on bucketName.
+func bucketPath(p model.Path, bucketName, field string) string {
+	return p.Join(Root, bucketName, field)
+}
+
+// Bucket returns the existing index bucket for bucketName/field, or nil if
+// no such index has been declared.
+func Bucket(tx *bolt.Tx, p model.Path, bucketName, field string) *bolt.Bucket {
+	return p.Bucket(tx, bucketPath(p, bucketName, field))
+}
+
+// Declared lists the fields with a declared index on bucketName.
+func Declared(tx *bolt.Tx, p model.Path, bucketName string) ([]string, error) {
+	root := p.Bucket(tx, p.Join(Root, bucketName))
+	if root == nil {
+		return nil, nil
+	}
+
+	var fields []string
+	err := root.ForEach(func(k, v []byte) error {
+		if v == nil {
+			fields = append(fields, string(k))
+		}
+		return nil
+	})
+	return fields, err
+}
+
+// IsUnique reports whether idx was declared with Create(unique=true).
+func IsUnique(idx *bolt.Bucket) bool {
+	return string(idx.Get([]byte(uniqueMarkerKey))) == "1"
+}
+
+// Create declares an index on bucketName's field (a dotted JSON path
+// evaluated against each key's value) and populates it from the bucket's
+// current contents.
+func Create(tx *bolt.Tx, p model.Path, bucketName, field string, unique bool) error {
+	idx, err := p.CreateBucket(tx, bucketPath(p, bucketName, field))
+	if err != nil {
+		return err
+	}
+
+	marker := "0"
+	if unique {
+		marker = "1"
+	}
+	if err := idx.Put([]byte(uniqueMarkerKey), []byte(marker)); err != nil {
+		return err
+	}
+
+	return Rebuild(tx, p, bucketName, field)
+}
+
+// Rebuild clears and repopulates the index on bucketName's field by
+// scanning the bucket's current keys.
+func Rebuild(tx *bolt.Tx, p model.Path, bucketName, field string) error {
+	bkt := p.Bucket(tx, bucketName)
+	if bkt == nil {
+		return fmt.Errorf("bucket %q does not exist", bucketName)
+	}
+
+	idx := Bucket(tx, p, bucketName, field)
+	if idx == nil {
+		return fmt.Errorf("no index declared on %s.%s", bucketName, field)
+	}
+	unique := IsUnique(idx)
+
+	if err := clear(idx); err != nil {
+		return err
+	}
+	if err := idx.Put([]byte(uniqueMarkerKey), []byte(map[bool]string{true: "1", false: "0"}[unique])); err != nil {
+		return err
+	}
+
+	return bkt.ForEach(func(k, v []byte) error {
+		if v == nil {
+			return nil // skip sub-buckets
+		}
+		return put(idx, field, k, v, unique)
+	})
+}
+
+// clear removes every entry from idx except the unique marker.
+func clear(idx *bolt.Bucket) error {
+	var keys [][]byte
+	c := idx.Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		if string(k) != uniqueMarkerKey {
+			keys = append(keys, append([]byte(nil), k...))
+		}
+	}
+	for _, k := range keys {
+		if err := idx.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Update keeps every index declared on bucketName in sync with a single
+// key's write or removal. oldValue is the key's value before this change
+// (nil if it didn't previously exist) and is used to retract any stale
+// index entry; newValue is the key's value after this change (nil if the
+// key is being deleted, in which case only retraction happens).
+func Update(tx *bolt.Tx, p model.Path, bucketName string, key, oldValue, newValue []byte) error {
+	fields, err := Declared(tx, p, bucketName)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		idx := Bucket(tx, p, bucketName, field)
+		if idx == nil {
+			continue
+		}
+		unique := IsUnique(idx)
+
+		if oldValue != nil {
+			if err := remove(idx, field, key, oldValue, unique); err != nil {
+				return err
+			}
+		}
+		if newValue != nil {
+			if err := put(idx, field, key, newValue, unique); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// put extracts field from value (parsed as JSON) and writes the index
+// entry for primaryKey, enforcing uniqueness when requested.
+func put(idx *bolt.Bucket, field string, primaryKey, value []byte, unique bool) error {
+	fvStr, ok := fieldValue(field, value)
+	if !ok {
+		return nil
+	}
+
+	if unique {
+		if existing := idx.Get([]byte(fvStr)); existing != nil && !bytes.Equal(existing, primaryKey) {
+			return fmt.Errorf("index: unique constraint violated for %s=%q", field, fvStr)
+		}
+		return idx.Put([]byte(fvStr), primaryKey)
+	}
+	return idx.Put([]byte(fvStr+"\x00"+string(primaryKey)), nil)
+}
+
+// remove retracts the index entry that put would have written for
+// primaryKey's previous value, undoing put's effect for the same
+// (field, primaryKey, value).
+func remove(idx *bolt.Bucket, field string, primaryKey, value []byte, unique bool) error {
+	fvStr, ok := fieldValue(field, value)
+	if !ok {
+		return nil
+	}
+
+	if unique {
+		if existing := idx.Get([]byte(fvStr)); existing != nil && bytes.Equal(existing, primaryKey) {
+			return idx.Delete([]byte(fvStr))
+		}
+		return nil
+	}
+	return idx.Delete([]byte(fvStr + "\x00" + string(primaryKey)))
+}
+
+// fieldValue parses value as JSON and extracts field (a dotted path),
+// returning ok=false if value isn't JSON or the path doesn't resolve.
+func fieldValue(field string, value []byte) (string, bool) {
+	var doc interface{}
+	if err := json.Unmarshal(value, &doc); err != nil {
+		return "", false
+	}
+
+	fv, ok := lookupPath(doc, strings.Split(field, "."))
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%v", fv), true
+}
+
+func lookupPath(doc interface{}, path []string) (interface{}, bool) {
+	cur := doc
+	for _, p := range path {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		cur, ok = m[p]
+		if !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// Find resolves bucketName's field=value via index lookup, returning the
+// matching primary keys without a full scan.
+func Find(tx *bolt.Tx, p model.Path, bucketName, field, value string) ([]string, error) {
+	idx := Bucket(tx, p, bucketName, field)
+	if idx == nil {
+		return nil, fmt.Errorf("no index declared on %s.%s", bucketName, field)
+	}
+
+	if IsUnique(idx) {
+		v := idx.Get([]byte(value))
+		if v == nil {
+			return nil, nil
+		}
+		return []string{string(v)}, nil
+	}
+
+	prefix := []byte(value + "\x00")
+	var keys []string
+	c := idx.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		keys = append(keys, string(k[len(prefix):]))
+	}
+	return keys, nil
+}
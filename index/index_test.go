@@ -0,0 +1,141 @@
+package index_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/boltdb/bolt"
+	"github.com/schmichael/boltq/index"
+	"github.com/schmichael/boltq/model"
+)
+
+func openTestDB(t *testing.T) *bolt.DB {
+	t.Helper()
+	f, err := os.CreateTemp("", "boltq-index-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	t.Cleanup(func() { os.Remove(f.Name()) })
+
+	db, err := bolt.Open(f.Name(), 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestUpdateRetractsStaleEntryOnChange(t *testing.T) {
+	db := openTestDB(t)
+	p := model.NewPath(".")
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		if _, err := p.CreateBucket(tx, "users"); err != nil {
+			return err
+		}
+		return index.Create(tx, p, "users", "status", false)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	put := func(key, value, oldValue string) {
+		t.Helper()
+		err := db.Update(func(tx *bolt.Tx) error {
+			bucket := p.Bucket(tx, "users")
+			if err := bucket.Put([]byte(key), []byte(value)); err != nil {
+				return err
+			}
+			var old []byte
+			if oldValue != "" {
+				old = []byte(oldValue)
+			}
+			return index.Update(tx, p, "users", []byte(key), old, []byte(value))
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	put("alice", `{"status":"active"}`, "")
+
+	find := func(value string) []string {
+		t.Helper()
+		var keys []string
+		err := db.View(func(tx *bolt.Tx) error {
+			var err error
+			keys, err = index.Find(tx, p, "users", "status", value)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return keys
+	}
+
+	if got := find("active"); len(got) != 1 || got[0] != "alice" {
+		t.Fatalf("find(active) = %v, want [alice]", got)
+	}
+
+	put("alice", `{"status":"inactive"}`, `{"status":"active"}`)
+
+	if got := find("active"); len(got) != 0 {
+		t.Fatalf("find(active) after update = %v, want none", got)
+	}
+	if got := find("inactive"); len(got) != 1 || got[0] != "alice" {
+		t.Fatalf("find(inactive) = %v, want [alice]", got)
+	}
+}
+
+func TestUpdateRetractsEntryOnDelete(t *testing.T) {
+	db := openTestDB(t)
+	p := model.NewPath(".")
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		if _, err := p.CreateBucket(tx, "users"); err != nil {
+			return err
+		}
+		return index.Create(tx, p, "users", "status", false)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		bucket := p.Bucket(tx, "users")
+		if err := bucket.Put([]byte("bob"), []byte(`{"status":"active"}`)); err != nil {
+			return err
+		}
+		return index.Update(tx, p, "users", []byte("bob"), nil, []byte(`{"status":"active"}`))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		bucket := p.Bucket(tx, "users")
+		old := bucket.Get([]byte("bob"))
+		if err := bucket.Delete([]byte("bob")); err != nil {
+			return err
+		}
+		return index.Update(tx, p, "users", []byte("bob"), old, nil)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.View(func(tx *bolt.Tx) error {
+		keys, err := index.Find(tx, p, "users", "status", "active")
+		if err != nil {
+			return err
+		}
+		if len(keys) != 0 {
+			t.Fatalf("find(active) after delete = %v, want none", keys)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
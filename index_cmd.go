@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/schmichael/boltq/index"
+	"github.com/schmichael/boltq/model"
+)
+
+// indexCmd implements `boltq index create|rebuild <path> <bucket> [field] [-unique]`.
+func indexCmd(args []string, sep string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: boltq index create|rebuild <path> <bucket> <jsonpath> [-unique]")
+	}
+
+	switch args[0] {
+	case "create":
+		return indexCreate(args[1:], sep)
+	case "rebuild":
+		return indexRebuild(args[1:], sep)
+	default:
+		return fmt.Errorf("unknown index subcommand %q", args[0])
+	}
+}
+
+func indexCreate(args []string, sep string) error {
+	unique := false
+	var rest []string
+	for _, a := range args {
+		if a == "-unique" {
+			unique = true
+			continue
+		}
+		rest = append(rest, a)
+	}
+	if len(rest) != 3 {
+		return fmt.Errorf("usage: boltq index create <path> <bucket> <jsonpath> [-unique]")
+	}
+	path, bucketName, field := rest[0], rest[1], rest[2]
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return fmt.Errorf("error opening db: %w", err)
+	}
+	defer db.Close()
+
+	p := model.NewPath(sep)
+	return db.Update(func(tx *bolt.Tx) error {
+		return index.Create(tx, p, bucketName, field, unique)
+	})
+}
+
+func indexRebuild(args []string, sep string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: boltq index rebuild <path> <bucket> [field]")
+	}
+	path, bucketName := args[0], args[1]
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return fmt.Errorf("error opening db: %w", err)
+	}
+	defer db.Close()
+
+	p := model.NewPath(sep)
+	return db.Update(func(tx *bolt.Tx) error {
+		if len(args) >= 3 {
+			return index.Rebuild(tx, p, bucketName, args[2])
+		}
+
+		fields, err := index.Declared(tx, p, bucketName)
+		if err != nil {
+			return err
+		}
+		for _, field := range fields {
+			if err := index.Rebuild(tx, p, bucketName, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// findCmd implements `boltq find <path> <bucket> <field>=<value>`, resolving
+// the match via a declared index instead of a full bucket scan.
+func findCmd(args []string, sep string) error {
+	if len(args) != 3 {
+		return fmt.Errorf("usage: boltq find <path> <bucket> <field>=<value>")
+	}
+	path, bucketName, expr := args[0], args[1], args[2]
+
+	parts := strings.SplitN(expr, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("expected <field>=<value>, got %q", expr)
+	}
+	field, value := parts[0], parts[1]
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("error opening db: %w", err)
+	}
+	defer db.Close()
+
+	p := model.NewPath(sep)
+	return db.View(func(tx *bolt.Tx) error {
+		keys, err := index.Find(tx, p, bucketName, field, value)
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			fmt.Println(k)
+		}
+		return nil
+	})
+}
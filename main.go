@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -9,6 +10,8 @@ import (
 	"time"
 
 	"github.com/boltdb/bolt"
+	"github.com/schmichael/boltq/model"
+	"github.com/schmichael/boltq/query"
 )
 
 func errf(format string, args ...interface{}) {
@@ -19,12 +22,67 @@ func main() {
 	sep := flag.String("sep", ".", "bucket separator")
 	verbose := flag.Bool("v", false, "verbose output")
 	tree := flag.Bool("tree", false, "dump bucket tree")
+	tui := flag.Bool("tui", false, "launch an interactive terminal browser")
+	serve := flag.String("serve", "", "serve the db over the Redis RESP protocol on addr, e.g. :6380")
+	where := flag.String("where", "", "filter expression for listing keys or dumping the tree, e.g. `size > 1024 and key ~ \"^foo\"`")
+	codecName := flag.String("codec", "json", "codec for dump/load: json, msgpack, or gob (must precede the dump/load subcommand)")
 	flag.Parse()
 	if flag.NArg() < 1 {
 		flag.Usage()
 		os.Exit(1)
 	}
 
+	switch flag.Arg(0) {
+	case "dump":
+		if err := dumpCmd(flag.Args()[1:], *sep, *codecName); err != nil {
+			errf("error: %v", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "load":
+		if err := loadCmd(flag.Args()[1:], *sep, *codecName); err != nil {
+			errf("error: %v", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "index":
+		if err := indexCmd(flag.Args()[1:], *sep); err != nil {
+			errf("error: %v", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "find":
+		if err := findCmd(flag.Args()[1:], *sep); err != nil {
+			errf("error: %v", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "cas":
+		if err := casCmd(flag.Args()[1:], *sep); err != nil {
+			errf("error: %v", err)
+			if errors.Is(err, ErrCASMismatch) {
+				os.Exit(2)
+			}
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "del":
+		if err := delCmd(flag.Args()[1:], *sep); err != nil {
+			errf("error: %v", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "batch":
+		if err := batchCmd(flag.Args()[1:], *sep); err != nil {
+			errf("error: %v", err)
+			if errors.Is(err, ErrCASMismatch) {
+				os.Exit(2)
+			}
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	path := flag.Arg(0)
 
 	f, err := os.Open(path)
@@ -41,10 +99,36 @@ func main() {
 	}
 	defer db.Close()
 
+	var whereNode query.Node
+	if *where != "" {
+		whereNode, err = query.Parse(*where)
+		if err != nil {
+			errf("error parsing -where: %v", err)
+			os.Exit(1)
+		}
+	}
+
 	c := &cli{
 		db:      db,
-		sep:     *sep,
+		path:    model.NewPath(*sep),
 		verbose: *verbose,
+		where:   whereNode,
+	}
+
+	if *serve != "" {
+		if err := c.serveRESP(*serve); err != nil {
+			errf("error: %v", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if *tui {
+		if err := runTUI(c); err != nil {
+			errf("error: %v", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
 	}
 
 	if *tree {
@@ -88,8 +172,20 @@ type cli struct {
 	db      *bolt.DB
 	verbose bool
 
-	// sep is the bucket name separator
-	sep string
+	// path resolves separator-delimited bucket names
+	path model.Path
+
+	// where, if set, filters listKeys and dumpTree output
+	where query.Node
+}
+
+// matches reports whether key/value passes c.where, or true if no -where
+// expression was given.
+func (c *cli) matches(key, value []byte) (bool, error) {
+	if c.where == nil {
+		return true, nil
+	}
+	return c.where.Eval(&query.Ctx{Key: string(key), Value: value})
 }
 
 func (c *cli) listBuckets() error {
@@ -121,27 +217,6 @@ func (c *cli) listBuckets() error {
 	})
 }
 
-// getBucket given a separator delimited name representing sub-buckets.
-func (c *cli) getBucket(tx *bolt.Tx, name string) *bolt.Bucket {
-	parts := strings.Split(name, c.sep)
-	if len(parts) == 0 {
-		return nil
-	}
-
-	b := tx.Bucket([]byte(parts[0]))
-	if b == nil {
-		return nil
-	}
-
-	for _, p := range parts[1:] {
-		b = b.Bucket([]byte(p))
-		if b == nil {
-			return nil
-		}
-	}
-	return b
-}
-
 func (c *cli) listKeys(name string) error {
 	tx, err := c.db.Begin(false)
 	if err != nil {
@@ -149,12 +224,22 @@ func (c *cli) listKeys(name string) error {
 	}
 	defer tx.Rollback()
 
-	bucket := c.getBucket(tx, name)
+	bucket := c.path.Bucket(tx, name)
 	if bucket == nil {
 		return fmt.Errorf("bucket %q does not exist", name)
 	}
 
 	return bucket.ForEach(func(k, v []byte) error {
+		if v != nil {
+			ok, err := c.matches(k, v)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return nil
+			}
+		}
+
 		if !c.verbose {
 			if v != nil {
 				// Skip sub-buckets
@@ -179,7 +264,7 @@ func (c *cli) getKey(bucketName, keyName string) error {
 	}
 	defer tx.Rollback()
 
-	bucket := c.getBucket(tx, bucketName)
+	bucket := c.path.Bucket(tx, bucketName)
 	if bucket == nil {
 		return fmt.Errorf("bucket %q does not exist", bucketName)
 	}
@@ -206,24 +291,7 @@ func (c *cli) getKey(bucketName, keyName string) error {
 
 func (c *cli) setKey(bucketName, keyName, value string) error {
 	return c.db.Update(func(tx *bolt.Tx) error {
-		parts := strings.Split(bucketName, c.sep)
-		if len(parts) == 0 {
-			return fmt.Errorf("invalid bucket: %q", parts)
-		}
-
-		bucket, err := tx.CreateBucketIfNotExists([]byte(parts[0]))
-		if err != nil {
-			return err
-		}
-
-		for _, p := range parts[1:] {
-			bucket, err = bucket.CreateBucketIfNotExists([]byte(p))
-			if err != nil {
-				return err
-			}
-		}
-
-		return bucket.Put([]byte(keyName), []byte(value))
+		return putKey(tx, c.path, bucketName, keyName, []byte(value))
 	})
 }
 
@@ -247,10 +315,18 @@ func (c *cli) displayBucket(bkt *bolt.Bucket, depth int) error {
 			kstr := string(k)
 			if v == nil {
 				buckets = append(buckets, kstr)
-			} else {
-				keys = append(keys, kstr)
-				vals[kstr] = len(v)
+				continue
+			}
+
+			ok, err := c.matches(k, v)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
 			}
+			keys = append(keys, kstr)
+			vals[kstr] = len(v)
 		}
 
 		sort.Strings(buckets)
@@ -0,0 +1,134 @@
+// Package model holds the bucket-path traversal logic shared by boltq's
+// one-shot CLI commands and its interactive TUI. Nothing in here prints to
+// stdout or touches flag.FlagSet; it only knows how to walk and mutate a
+// *bolt.DB given a separator-delimited bucket path.
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/boltdb/bolt"
+)
+
+// Path splits and joins bucket names using a configured separator, e.g.
+// "a.b.c" with Sep "." names the bucket c nested in b, nested in a.
+type Path struct {
+	Sep string
+}
+
+// NewPath returns a Path using sep to delimit nested bucket names.
+func NewPath(sep string) Path {
+	return Path{Sep: sep}
+}
+
+// Split breaks name into its component bucket names.
+func (p Path) Split(name string) []string {
+	return strings.Split(name, p.Sep)
+}
+
+// Join reassembles parts into a single separator-delimited name.
+func (p Path) Join(parts ...string) string {
+	return strings.Join(parts, p.Sep)
+}
+
+// Bucket walks tx to the bucket identified by name, returning nil if any
+// part of the path does not exist.
+func (p Path) Bucket(tx *bolt.Tx, name string) *bolt.Bucket {
+	parts := p.Split(name)
+	if len(parts) == 0 {
+		return nil
+	}
+
+	b := tx.Bucket([]byte(parts[0]))
+	if b == nil {
+		return nil
+	}
+
+	for _, part := range parts[1:] {
+		b = b.Bucket([]byte(part))
+		if b == nil {
+			return nil
+		}
+	}
+	return b
+}
+
+// CreateBucket walks tx to the bucket identified by name, creating any
+// missing buckets along the way.
+func (p Path) CreateBucket(tx *bolt.Tx, name string) (*bolt.Bucket, error) {
+	parts := p.Split(name)
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("invalid bucket: %q", name)
+	}
+
+	b, err := tx.CreateBucketIfNotExists([]byte(parts[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, part := range parts[1:] {
+		b, err = b.CreateBucketIfNotExists([]byte(part))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// Entry is a single child of a bucket: either a nested bucket or a key/value
+// pair. It's the unit the TUI renders per tree node.
+type Entry struct {
+	Name     string
+	IsBucket bool
+	Value    []byte // nil when IsBucket
+}
+
+// Children lists the immediate entries of bkt in cursor order. If bkt is
+// nil, the entries of the top-level tx (root buckets) are returned.
+func Children(tx *bolt.Tx, bkt *bolt.Bucket) []Entry {
+	var cur *bolt.Cursor
+	if bkt == nil {
+		cur = tx.Cursor()
+	} else {
+		cur = bkt.Cursor()
+	}
+
+	var entries []Entry
+	for k, v := cur.First(); k != nil; k, v = cur.Next() {
+		entries = append(entries, Entry{
+			Name:     string(k),
+			IsBucket: v == nil,
+			Value:    v,
+		})
+	}
+	return entries
+}
+
+// DeleteBucket removes the bucket identified by name, which may be nested.
+func (p Path) DeleteBucket(tx *bolt.Tx, name string) error {
+	parts := p.Split(name)
+	if len(parts) == 0 {
+		return fmt.Errorf("invalid bucket: %q", name)
+	}
+
+	if len(parts) == 1 {
+		return tx.DeleteBucket([]byte(parts[0]))
+	}
+
+	parent := p.Bucket(tx, p.Join(parts[:len(parts)-1]...))
+	if parent == nil {
+		return fmt.Errorf("bucket %q does not exist", p.Join(parts[:len(parts)-1]...))
+	}
+	return parent.DeleteBucket([]byte(parts[len(parts)-1]))
+}
+
+// Stats returns the bucket stats for name, or false if the bucket does not
+// exist.
+func (p Path) Stats(tx *bolt.Tx, name string) (bolt.BucketStats, bool) {
+	b := p.Bucket(tx, name)
+	if b == nil {
+		return bolt.BucketStats{}, false
+	}
+	return b.Stats(), true
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/schmichael/boltq/index"
+	"github.com/schmichael/boltq/model"
+)
+
+// ErrCASMismatch is returned by cas when the key's current value doesn't
+// match the caller's expected value. main checks for it to exit(2) so
+// callers (shell scripts, batch files) can distinguish a lost race from a
+// hard error.
+var ErrCASMismatch = errors.New("cas: expected value does not match")
+
+// casCmd implements `boltq cas <path> <bucket> <key> <expected> <new>`.
+func casCmd(args []string, sep string) error {
+	if len(args) != 5 {
+		return fmt.Errorf("usage: boltq cas <path> <bucket> <key> <expected> <new>")
+	}
+	path, bucketName, key, expected, newVal := args[0], args[1], args[2], args[3], args[4]
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return fmt.Errorf("error opening db: %w", err)
+	}
+	defer db.Close()
+
+	p := model.NewPath(sep)
+	return db.Update(func(tx *bolt.Tx) error {
+		return cas(tx, p, bucketName, key, expected, newVal)
+	})
+}
+
+// cas performs a compare-and-swap of bucketName/key, succeeding only if the
+// key's current value equals expected (the empty string matches a missing
+// key).
+func cas(tx *bolt.Tx, p model.Path, bucketName, key, expected, newVal string) error {
+	bucket, err := p.CreateBucket(tx, bucketName)
+	if err != nil {
+		return err
+	}
+
+	if string(bucket.Get([]byte(key))) != expected {
+		return ErrCASMismatch
+	}
+
+	return putKey(tx, p, bucketName, key, []byte(newVal))
+}
+
+// putKey creates bucketName as needed, puts value at key, and retracts/adds
+// index entries for the key's old and new value, all within tx. It's the
+// one place every key put (the set and cas commands, RESP, the TUI, batch
+// scripts) funnels through so they can't drift out of sync with the index
+// subsystem. Deletes go through deleteKey or respDelKey instead, and load
+// writes keys directly without touching indexes.
+func putKey(tx *bolt.Tx, p model.Path, bucketName, key string, value []byte) error {
+	bucket, err := p.CreateBucket(tx, bucketName)
+	if err != nil {
+		return err
+	}
+
+	oldValue := append([]byte(nil), bucket.Get([]byte(key))...)
+	if err := bucket.Put([]byte(key), value); err != nil {
+		return err
+	}
+	return index.Update(tx, p, bucketName, []byte(key), oldValue, value)
+}
+
+// delCmd implements `boltq del <path> <bucket> <key>`.
+func delCmd(args []string, sep string) error {
+	if len(args) != 3 {
+		return fmt.Errorf("usage: boltq del <path> <bucket> <key>")
+	}
+	path, bucketName, key := args[0], args[1], args[2]
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return fmt.Errorf("error opening db: %w", err)
+	}
+	defer db.Close()
+
+	p := model.NewPath(sep)
+	return db.Update(func(tx *bolt.Tx) error {
+		return deleteKey(tx, p, bucketName, key)
+	})
+}
+
+// deleteKey removes key from bucketName and retracts any stale index
+// entries for its prior value, all within tx.
+func deleteKey(tx *bolt.Tx, p model.Path, bucketName, key string) error {
+	bucket := p.Bucket(tx, bucketName)
+	if bucket == nil {
+		return fmt.Errorf("bucket %q does not exist", bucketName)
+	}
+
+	oldValue := append([]byte(nil), bucket.Get([]byte(key))...)
+	if err := bucket.Delete([]byte(key)); err != nil {
+		return err
+	}
+	if oldValue == nil {
+		return nil
+	}
+	return index.Update(tx, p, bucketName, []byte(key), oldValue, nil)
+}
+
+// batchCmd implements `boltq batch <path> -f <script>`, applying every
+// command in script inside a single db.Update, aborting the whole
+// transaction if any command fails.
+func batchCmd(args []string, sep string) error {
+	var path, scriptPath string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-f":
+			i++
+			if i >= len(args) {
+				return fmt.Errorf("usage: boltq batch <path> -f <script>")
+			}
+			scriptPath = args[i]
+		default:
+			if path == "" {
+				path = args[i]
+			}
+		}
+	}
+	if path == "" || scriptPath == "" {
+		return fmt.Errorf("usage: boltq batch <path> -f <script>")
+	}
+
+	f, err := os.Open(scriptPath)
+	if err != nil {
+		return fmt.Errorf("error opening batch script: %w", err)
+	}
+	defer f.Close()
+
+	lines, err := parseBatch(f)
+	if err != nil {
+		return err
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return fmt.Errorf("error opening db: %w", err)
+	}
+	defer db.Close()
+
+	p := model.NewPath(sep)
+	return db.Update(func(tx *bolt.Tx) error {
+		for i, line := range lines {
+			if err := applyBatchLine(tx, p, line); err != nil {
+				return fmt.Errorf("line %d (%s): %w", i+1, line.name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// batchLine is one parsed, validated line of a batch script.
+type batchLine struct {
+	name string
+	args []string
+}
+
+// parseBatch reads newline-delimited batch commands, skipping blank lines
+// and "#"-prefixed comments.
+func parseBatch(r *os.File) ([]batchLine, error) {
+	var lines []batchLine
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.SplitN(text, " ", 2)
+		name := fields[0]
+		var rest string
+		if len(fields) == 2 {
+			rest = fields[1]
+		}
+
+		var nargs []string
+		var err error
+		switch name {
+		case "set":
+			nargs, err = splitFields(rest, 3)
+		case "del":
+			nargs, err = splitFields(rest, 2)
+		case "cas":
+			nargs, err = splitFields(rest, 4)
+		case "mkbucket", "rmbucket":
+			nargs, err = splitFields(rest, 1)
+		default:
+			err = fmt.Errorf("unknown batch command %q", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		lines = append(lines, batchLine{name: name, args: nargs})
+	}
+	return lines, scanner.Err()
+}
+
+// splitFields splits rest into exactly n fields, allowing the final field
+// (a value) to contain spaces.
+func splitFields(rest string, n int) ([]string, error) {
+	fields := strings.SplitN(rest, " ", n)
+	if len(fields) != n || fields[0] == "" {
+		return nil, fmt.Errorf("expected %d arguments, got %q", n, rest)
+	}
+	return fields, nil
+}
+
+// applyBatchLine applies a single parsed batch command within tx.
+func applyBatchLine(tx *bolt.Tx, p model.Path, line batchLine) error {
+	switch line.name {
+	case "set":
+		bucketName, key, value := line.args[0], line.args[1], line.args[2]
+		return putKey(tx, p, bucketName, key, []byte(value))
+	case "del":
+		bucketName, key := line.args[0], line.args[1]
+		return deleteKey(tx, p, bucketName, key)
+	case "cas":
+		bucketName, key, expected, newVal := line.args[0], line.args[1], line.args[2], line.args[3]
+		return cas(tx, p, bucketName, key, expected, newVal)
+	case "mkbucket":
+		_, err := p.CreateBucket(tx, line.args[0])
+		return err
+	case "rmbucket":
+		return p.DeleteBucket(tx, line.args[0])
+	default:
+		return fmt.Errorf("unknown batch command %q", line.name)
+	}
+}
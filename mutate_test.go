@@ -0,0 +1,228 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/boltdb/bolt"
+	"github.com/schmichael/boltq/model"
+)
+
+func openMutateTestDB(t *testing.T) *bolt.DB {
+	t.Helper()
+	f, err := os.CreateTemp("", "boltq-mutate-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	t.Cleanup(func() { os.Remove(f.Name()) })
+
+	db, err := bolt.Open(f.Name(), 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestCasSucceedsWhenExpectedMatches(t *testing.T) {
+	db := openMutateTestDB(t)
+	p := model.NewPath(".")
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		return cas(tx, p, "users", "alice", "", "1")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		return cas(tx, p, "users", "alice", "1", "2")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.View(func(tx *bolt.Tx) error {
+		b := p.Bucket(tx, "users")
+		if got := string(b.Get([]byte("alice"))); got != "2" {
+			t.Errorf("alice = %q, want %q", got, "2")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCasFailsWhenExpectedMismatches(t *testing.T) {
+	db := openMutateTestDB(t)
+	p := model.NewPath(".")
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		return cas(tx, p, "users", "alice", "", "1")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		return cas(tx, p, "users", "alice", "wrong", "2")
+	})
+	if !errors.Is(err, ErrCASMismatch) {
+		t.Fatalf("err = %v, want ErrCASMismatch", err)
+	}
+
+	err = db.View(func(tx *bolt.Tx) error {
+		b := p.Bucket(tx, "users")
+		if got := string(b.Get([]byte("alice"))); got != "1" {
+			t.Errorf("alice = %q, want unchanged %q", got, "1")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteKeyRemovesValue(t *testing.T) {
+	db := openMutateTestDB(t)
+	p := model.NewPath(".")
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		return putKey(tx, p, "users", "alice", []byte("1"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		return deleteKey(tx, p, "users", "alice")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.View(func(tx *bolt.Tx) error {
+		b := p.Bucket(tx, "users")
+		if got := b.Get([]byte("alice")); got != nil {
+			t.Errorf("alice = %q, want deleted", got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteKeyMissingBucket(t *testing.T) {
+	db := openMutateTestDB(t)
+	p := model.NewPath(".")
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		return deleteKey(tx, p, "nope", "alice")
+	})
+	if err == nil {
+		t.Fatal("expected error for missing bucket, got nil")
+	}
+}
+
+func writeBatchScript(t *testing.T, contents string) *os.File {
+	t.Helper()
+	f, err := os.CreateTemp("", "boltq-batch-script-*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(f.Name()) })
+	if _, err := f.WriteString(contents); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestParseBatch(t *testing.T) {
+	script := writeBatchScript(t, `
+# a comment
+mkbucket users
+set users alice hello world
+cas users alice hello goodbye world
+del users alice
+rmbucket users
+`)
+
+	lines, err := parseBatch(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []batchLine{
+		{name: "mkbucket", args: []string{"users"}},
+		{name: "set", args: []string{"users", "alice", "hello world"}},
+		{name: "cas", args: []string{"users", "alice", "hello", "goodbye world"}},
+		{name: "del", args: []string{"users", "alice"}},
+		{name: "rmbucket", args: []string{"users"}},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d: %+v", len(lines), len(want), lines)
+	}
+	for i, w := range want {
+		if lines[i].name != w.name || strings.Join(lines[i].args, "|") != strings.Join(w.args, "|") {
+			t.Errorf("line %d = %+v, want %+v", i, lines[i], w)
+		}
+	}
+}
+
+func TestParseBatchUnknownCommand(t *testing.T) {
+	script := writeBatchScript(t, "bogus foo bar\n")
+	_, err := parseBatch(script)
+	if err == nil {
+		t.Fatal("expected error for unknown batch command, got nil")
+	}
+}
+
+func TestParseBatchWrongArgCount(t *testing.T) {
+	script := writeBatchScript(t, "set users alice\n")
+	_, err := parseBatch(script)
+	if err == nil {
+		t.Fatal("expected error for missing value, got nil")
+	}
+}
+
+// TestBatchCasMismatchIsDistinguishable exercises batchCmd end to end,
+// confirming a cas mismatch inside a batch script still satisfies
+// errors.Is(err, ErrCASMismatch) once wrapped with line context, so
+// main.go's "batch" case can exit(2) the same way the standalone "cas"
+// command does.
+func TestBatchCasMismatchIsDistinguishable(t *testing.T) {
+	db := openMutateTestDB(t)
+	dbPath := db.Path()
+	p := model.NewPath(".")
+	err := db.Update(func(tx *bolt.Tx) error {
+		return putKey(tx, p, "users", "alice", []byte("1"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	script, err := os.CreateTemp("", "boltq-batch-test-*.script")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(script.Name()) })
+	if _, err := script.WriteString("cas users alice wrong 2\n"); err != nil {
+		t.Fatal(err)
+	}
+	script.Close()
+
+	err = batchCmd([]string{dbPath, "-f", script.Name()}, ".")
+	if !errors.Is(err, ErrCASMismatch) {
+		t.Fatalf("err = %v, want wrapped ErrCASMismatch", err)
+	}
+}
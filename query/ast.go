@@ -0,0 +1,78 @@
+package query
+
+import "regexp"
+
+// Node is one predicate in a -where expression's AST. Eval is called once
+// per key/value pair streamed through bucket.ForEach.
+type Node interface {
+	Eval(c *Ctx) (bool, error)
+}
+
+// And is true when both operands are true.
+type And struct {
+	Left, Right Node
+}
+
+func (n *And) Eval(c *Ctx) (bool, error) {
+	l, err := n.Left.Eval(c)
+	if err != nil || !l {
+		return false, err
+	}
+	return n.Right.Eval(c)
+}
+
+// Or is true when either operand is true.
+type Or struct {
+	Left, Right Node
+}
+
+func (n *Or) Eval(c *Ctx) (bool, error) {
+	l, err := n.Left.Eval(c)
+	if err != nil || l {
+		return l, err
+	}
+	return n.Right.Eval(c)
+}
+
+// Not negates its operand.
+type Not struct {
+	Node Node
+}
+
+func (n *Not) Eval(c *Ctx) (bool, error) {
+	v, err := n.Node.Eval(c)
+	if err != nil {
+		return false, err
+	}
+	return !v, nil
+}
+
+// Op is a comparison or matching operator usable in a Cmp node.
+type Op int
+
+const (
+	OpEq Op = iota
+	OpNe
+	OpGt
+	OpGe
+	OpLt
+	OpLe
+	OpMatch    // ~ "regex"
+	OpContains // contains "substr"
+)
+
+// Cmp compares the field named by Field (one of "key", "size", "value", or
+// a "value.<jsonpath>" accessor) against Literal using Op.
+type Cmp struct {
+	Field   string
+	Op      Op
+	Literal interface{} // string or float64
+
+	// re is the compiled form of Literal for OpMatch, set once by Parse so
+	// Eval never recompiles it on the hot path of streaming over a bucket.
+	re *regexp.Regexp
+}
+
+func (n *Cmp) Eval(c *Ctx) (bool, error) {
+	return evalCmp(c, n)
+}
@@ -0,0 +1,140 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Ctx is the (key, value) pair a Node is evaluated against. JSON decoding
+// of value only happens the first time a "value.<path>" accessor is
+// referenced, so predicates that never touch JSON pay nothing for it.
+type Ctx struct {
+	Key   string
+	Value []byte
+
+	decoded    interface{}
+	decodeOK   bool
+	decodeOnce bool
+}
+
+func (c *Ctx) json() (interface{}, bool) {
+	if !c.decodeOnce {
+		c.decodeOnce = true
+		var v interface{}
+		if err := json.Unmarshal(c.Value, &v); err == nil {
+			c.decoded = v
+			c.decodeOK = true
+		}
+	}
+	return c.decoded, c.decodeOK
+}
+
+// fieldValue resolves "key", "size", "value", or "value.<jsonpath>" against
+// the current pair.
+func (c *Ctx) fieldValue(field string) (interface{}, error) {
+	switch {
+	case field == "key":
+		return c.Key, nil
+	case field == "size":
+		return float64(len(c.Value)), nil
+	case field == "value":
+		return string(c.Value), nil
+	case strings.HasPrefix(field, "value."):
+		doc, ok := c.json()
+		if !ok {
+			return nil, nil
+		}
+		return lookupPath(doc, strings.Split(field[len("value."):], "."))
+	default:
+		return nil, fmt.Errorf("query: unknown field %q", field)
+	}
+}
+
+func lookupPath(doc interface{}, path []string) (interface{}, error) {
+	cur := doc
+	for _, p := range path {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+		cur, ok = m[p]
+		if !ok {
+			return nil, nil
+		}
+	}
+	return cur, nil
+}
+
+func evalCmp(c *Ctx, n *Cmp) (bool, error) {
+	lhs, err := c.fieldValue(n.Field)
+	if err != nil {
+		return false, err
+	}
+	if lhs == nil {
+		return false, nil
+	}
+
+	switch n.Op {
+	case OpMatch:
+		return n.re.MatchString(toString(lhs)), nil
+	case OpContains:
+		return strings.Contains(toString(lhs), n.Literal.(string)), nil
+	case OpEq, OpNe:
+		eq := equal(lhs, n.Literal)
+		if n.Op == OpNe {
+			return !eq, nil
+		}
+		return eq, nil
+	case OpGt, OpGe, OpLt, OpLe:
+		lf, ok := toFloat(lhs)
+		rf, ok2 := toFloat(n.Literal)
+		if !ok || !ok2 {
+			return false, fmt.Errorf("query: %q is not numeric", n.Field)
+		}
+		switch n.Op {
+		case OpGt:
+			return lf > rf, nil
+		case OpGe:
+			return lf >= rf, nil
+		case OpLt:
+			return lf < rf, nil
+		case OpLe:
+			return lf <= rf, nil
+		}
+	}
+	return false, fmt.Errorf("query: unhandled operator")
+}
+
+func toString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'g', -1, 64)
+	default:
+		return fmt.Sprintf("%v", t)
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case string:
+		f, err := strconv.ParseFloat(t, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func equal(a, b interface{}) bool {
+	if af, ok := toFloat(a); ok {
+		if bf, ok := toFloat(b); ok {
+			return af == bf
+		}
+	}
+	return toString(a) == toString(b)
+}
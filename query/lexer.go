@@ -0,0 +1,128 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+}
+
+// lexer turns a -where expression into a stream of tokens.
+type lexer struct {
+	input []rune
+	pos   int
+}
+
+func newLexer(s string) *lexer {
+	return &lexer{input: []rune(s)}
+}
+
+func (l *lexer) peekRune() rune {
+	if l.pos >= len(l.input) {
+		return 0
+	}
+	return l.input[l.pos]
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipSpace()
+	if l.pos >= len(l.input) {
+		return token{kind: tokEOF}, nil
+	}
+
+	r := l.input[l.pos]
+	switch {
+	case r == '(':
+		l.pos++
+		return token{kind: tokLParen}, nil
+	case r == ')':
+		l.pos++
+		return token{kind: tokRParen}, nil
+	case r == '"':
+		return l.lexString()
+	case r == '~':
+		l.pos++
+		return token{kind: tokOp, text: "~"}, nil
+	case strings.ContainsRune("=!<>", r):
+		return l.lexOp()
+	case unicode.IsDigit(r):
+		return l.lexNumber()
+	case unicode.IsLetter(r) || r == '_':
+		return l.lexIdent()
+	default:
+		return token{}, fmt.Errorf("query: unexpected character %q", r)
+	}
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.input) && unicode.IsSpace(l.input[l.pos]) {
+		l.pos++
+	}
+}
+
+func (l *lexer) lexString() (token, error) {
+	l.pos++ // opening quote
+	start := l.pos
+	for l.pos < len(l.input) && l.input[l.pos] != '"' {
+		l.pos++
+	}
+	if l.pos >= len(l.input) {
+		return token{}, fmt.Errorf("query: unterminated string literal")
+	}
+	s := string(l.input[start:l.pos])
+	l.pos++ // closing quote
+	return token{kind: tokString, text: s}, nil
+}
+
+func (l *lexer) lexOp() (token, error) {
+	start := l.pos
+	l.pos++
+	if l.pos < len(l.input) && l.input[l.pos] == '=' {
+		l.pos++
+	}
+	op := string(l.input[start:l.pos])
+	switch op {
+	case "==", "!=", ">=", "<=", ">", "<":
+		return token{kind: tokOp, text: op}, nil
+	}
+	return token{}, fmt.Errorf("query: unknown operator %q", op)
+}
+
+func (l *lexer) lexNumber() (token, error) {
+	start := l.pos
+	for l.pos < len(l.input) && (unicode.IsDigit(l.input[l.pos]) || l.input[l.pos] == '.') {
+		l.pos++
+	}
+	s := string(l.input[start:l.pos])
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return token{}, fmt.Errorf("query: invalid number %q: %w", s, err)
+	}
+	return token{kind: tokNumber, num: n, text: s}, nil
+}
+
+func (l *lexer) lexIdent() (token, error) {
+	start := l.pos
+	for l.pos < len(l.input) && (unicode.IsLetter(l.input[l.pos]) || unicode.IsDigit(l.input[l.pos]) || l.input[l.pos] == '_' || l.input[l.pos] == '.') {
+		l.pos++
+	}
+	return token{kind: tokIdent, text: string(l.input[start:l.pos])}, nil
+}
@@ -0,0 +1,193 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Parse compiles a -where expression into a Node tree.
+//
+// Grammar:
+//
+//	expr   := orExpr
+//	orExpr := andExpr ("or" andExpr)*
+//	andExpr:= unary ("and" unary)*
+//	unary  := "not" unary | "(" expr ")" | cmp
+//	cmp    := ident op literal
+//	ident  := "key" | "size" | "value" | "value" "." path
+//	op     := "==" | "!=" | ">" | ">=" | "<" | "<=" | "~" | "contains"
+func Parse(s string) (Node, error) {
+	p := &parser{lex: newLexer(s)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.cur.kind != tokEOF {
+		return nil, fmt.Errorf("query: unexpected trailing token %q", p.cur.text)
+	}
+	return n, nil
+}
+
+type parser struct {
+	lex *lexer
+	cur token
+}
+
+func (p *parser) advance() error {
+	t, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.cur = t
+	return nil
+}
+
+func (p *parser) isKeyword(kw string) bool {
+	return p.cur.kind == tokIdent && p.cur.text == kw
+}
+
+func (p *parser) parseOr() (Node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("or") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("and") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &And{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	if p.isKeyword("not") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Node: n}, nil
+	}
+
+	if p.cur.kind == tokLParen {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.cur.kind != tokRParen {
+			return nil, fmt.Errorf("query: expected ')'")
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		return n, nil
+	}
+
+	return p.parseCmp()
+}
+
+func (p *parser) parseCmp() (Node, error) {
+	if p.cur.kind != tokIdent {
+		return nil, fmt.Errorf("query: expected field name, got %q", p.cur.text)
+	}
+	field := p.cur.text
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	if p.isKeyword("contains") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.cur.kind != tokString {
+			return nil, fmt.Errorf("query: expected string after 'contains'")
+		}
+		lit := p.cur.text
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		return &Cmp{Field: field, Op: OpContains, Literal: lit}, nil
+	}
+
+	var op Op
+	switch p.cur.text {
+	case "~":
+		op = OpMatch
+	case "==":
+		op = OpEq
+	case "!=":
+		op = OpNe
+	case ">":
+		op = OpGt
+	case ">=":
+		op = OpGe
+	case "<":
+		op = OpLt
+	case "<=":
+		op = OpLe
+	default:
+		return nil, fmt.Errorf("query: expected operator, got %q", p.cur.text)
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	switch p.cur.kind {
+	case tokString:
+		lit := p.cur.text
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		cmp := &Cmp{Field: field, Op: op, Literal: lit}
+		if op == OpMatch {
+			re, err := regexp.Compile(lit)
+			if err != nil {
+				return nil, fmt.Errorf("query: invalid regex %q: %w", lit, err)
+			}
+			cmp.re = re
+		}
+		return cmp, nil
+	case tokNumber:
+		if op == OpMatch {
+			return nil, fmt.Errorf("query: expected string after '~'")
+		}
+		lit := p.cur.num
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		return &Cmp{Field: field, Op: op, Literal: lit}, nil
+	default:
+		return nil, fmt.Errorf("query: expected literal, got %q", p.cur.text)
+	}
+}
@@ -0,0 +1,41 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/schmichael/boltq/query"
+)
+
+func TestParseCompilesMatchRegexOnce(t *testing.T) {
+	n, err := query.Parse(`key ~ "^a.*"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmp, ok := n.(*query.Cmp)
+	if !ok {
+		t.Fatalf("Parse returned %T, want *query.Cmp", n)
+	}
+
+	ok, err = cmp.Eval(&query.Ctx{Key: "alice", Value: []byte("1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("expected key ~ \"^a.*\" to match \"alice\"")
+	}
+
+	ok, err = cmp.Eval(&query.Ctx{Key: "bob", Value: []byte("1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Fatal("expected key ~ \"^a.*\" not to match \"bob\"")
+	}
+}
+
+func TestParseRejectsInvalidRegex(t *testing.T) {
+	if _, err := query.Parse(`key ~ "("`); err == nil {
+		t.Fatal("expected an error for an unparseable regex")
+	}
+}
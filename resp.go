@@ -0,0 +1,386 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/boltdb/bolt"
+	"github.com/schmichael/boltq/index"
+	"github.com/schmichael/boltq/model"
+	"github.com/tidwall/match"
+	"github.com/tidwall/redcon"
+)
+
+// respConn tracks the MULTI/EXEC queuing state for a single client
+// connection.
+type respConn struct {
+	queued  []redcon.Command
+	inMulti bool
+	dirty   bool // set when a command queued inside MULTI can't be applied, aborting EXEC
+}
+
+// respMultiSupported lists the commands applyInTx knows how to replay
+// inside a MULTI/EXEC transaction.
+var respMultiSupported = map[string]bool{"SET": true, "DEL": true, "GET": true}
+
+// serveRESP exposes c.db over the Redis RESP protocol at addr, so any Redis
+// client can GET/SET/DEL/KEYS/SCAN/HGETALL against the opened bolt file.
+// Bucket paths are derived from keys using c.path's separator, e.g.
+// "SET a.b.c value" walks/creates buckets a -> b and puts key c.
+func (c *cli) serveRESP(addr string) error {
+	srv := redcon.NewServer(addr,
+		func(conn redcon.Conn, cmd redcon.Command) {
+			c.handleRESP(conn, cmd)
+		},
+		func(conn redcon.Conn) bool { return true },
+		func(conn redcon.Conn, err error) {},
+	)
+	return srv.ListenAndServe()
+}
+
+func (c *cli) handleRESP(conn redcon.Conn, cmd redcon.Command) {
+	name := strings.ToUpper(string(cmd.Args[0]))
+
+	if rc, ok := conn.Context().(*respConn); ok && rc.inMulti && name != "EXEC" && name != "DISCARD" {
+		if !respMultiSupported[name] {
+			rc.dirty = true
+			conn.WriteError(fmt.Sprintf("ERR '%s' is not supported inside MULTI", name))
+			return
+		}
+		rc.queued = append(rc.queued, cmd)
+		conn.WriteString("QUEUED")
+		return
+	}
+
+	switch name {
+	case "MULTI":
+		conn.SetContext(&respConn{inMulti: true})
+		conn.WriteString("OK")
+	case "EXEC":
+		c.execMulti(conn)
+	case "DISCARD":
+		conn.SetContext(nil)
+		conn.WriteString("OK")
+	case "GET":
+		c.respGet(conn, cmd)
+	case "SET":
+		c.respSet(conn, cmd)
+	case "DEL":
+		c.respDel(conn, cmd)
+	case "KEYS":
+		c.respKeys(conn, cmd)
+	case "SCAN":
+		c.respScan(conn, cmd)
+	case "HGETALL":
+		c.respHGetAll(conn, cmd)
+	case "PING":
+		conn.WriteString("PONG")
+	default:
+		conn.WriteError(fmt.Sprintf("ERR unknown command '%s'", name))
+	}
+}
+
+// execMulti runs every command queued since MULTI inside a single
+// db.Update, so the whole batch is atomic, then replies with each
+// command's own result in order, per RESP's EXEC semantics.
+func (c *cli) execMulti(conn redcon.Conn) {
+	rc, ok := conn.Context().(*respConn)
+	if !ok || !rc.inMulti {
+		conn.WriteError("ERR EXEC without MULTI")
+		return
+	}
+	conn.SetContext(nil)
+
+	if rc.dirty {
+		conn.WriteError("ERR EXEC aborted due to previous errors")
+		return
+	}
+
+	replies := make([]queuedReply, len(rc.queued))
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		for i, cmd := range rc.queued {
+			reply, err := c.applyInTx(tx, cmd)
+			if err != nil {
+				return err
+			}
+			replies[i] = reply
+		}
+		return nil
+	})
+	if err != nil {
+		conn.WriteError(fmt.Sprintf("ERR %v", err))
+		return
+	}
+	conn.WriteArray(len(replies))
+	for _, reply := range replies {
+		reply.writeTo(conn)
+	}
+}
+
+// queuedReply is the RESP reply produced by one command applied inside a
+// MULTI/EXEC transaction, deferred until EXEC writes the whole array.
+type queuedReply struct {
+	str    string
+	bulk   []byte
+	isNull bool
+	isInt  bool
+	n      int
+}
+
+func (r queuedReply) writeTo(conn redcon.Conn) {
+	switch {
+	case r.isNull:
+		conn.WriteNull()
+	case r.isInt:
+		conn.WriteInt(r.n)
+	case r.bulk != nil:
+		conn.WriteBulk(r.bulk)
+	default:
+		conn.WriteString(r.str)
+	}
+}
+
+// applyInTx applies a single command within an already-open transaction,
+// for use inside MULTI/EXEC batches, returning the reply EXEC should give
+// for it. Only commands listed in respMultiSupported reach here.
+func (c *cli) applyInTx(tx *bolt.Tx, cmd redcon.Command) (queuedReply, error) {
+	switch strings.ToUpper(string(cmd.Args[0])) {
+	case "SET":
+		if len(cmd.Args) != 3 {
+			return queuedReply{}, fmt.Errorf("wrong number of arguments for 'set'")
+		}
+		bucketPath, key := splitKey(c.path, string(cmd.Args[1]))
+		if err := putKey(tx, c.path, bucketPath, key, cmd.Args[2]); err != nil {
+			return queuedReply{}, err
+		}
+		return queuedReply{str: "OK"}, nil
+	case "DEL":
+		if len(cmd.Args) != 2 {
+			return queuedReply{}, fmt.Errorf("wrong number of arguments for 'del'")
+		}
+		bucketPath, key := splitKey(c.path, string(cmd.Args[1]))
+		deleted := 0
+		if b := c.path.Bucket(tx, bucketPath); b != nil && b.Get([]byte(key)) != nil {
+			deleted = 1
+		}
+		if err := respDelKey(tx, c.path, bucketPath, key); err != nil {
+			return queuedReply{}, err
+		}
+		return queuedReply{isInt: true, n: deleted}, nil
+	case "GET":
+		if len(cmd.Args) != 2 {
+			return queuedReply{}, fmt.Errorf("wrong number of arguments for 'get'")
+		}
+		bucketPath, key := splitKey(c.path, string(cmd.Args[1]))
+		b := c.path.Bucket(tx, bucketPath)
+		if b == nil {
+			return queuedReply{isNull: true}, nil
+		}
+		v := b.Get([]byte(key))
+		if v == nil {
+			return queuedReply{isNull: true}, nil
+		}
+		return queuedReply{bulk: append([]byte(nil), v...)}, nil
+	default:
+		return queuedReply{}, fmt.Errorf("'%s' is not supported inside MULTI", cmd.Args[0])
+	}
+}
+
+// respDelKey removes key from bucketPath and retracts any stale index
+// entries for its prior value, all within tx.
+func respDelKey(tx *bolt.Tx, p model.Path, bucketPath, key string) error {
+	b := p.Bucket(tx, bucketPath)
+	if b == nil {
+		return nil
+	}
+
+	oldValue := append([]byte(nil), b.Get([]byte(key))...)
+	if err := b.Delete([]byte(key)); err != nil {
+		return err
+	}
+	if oldValue == nil {
+		return nil
+	}
+	return index.Update(tx, p, bucketPath, []byte(key), oldValue, nil)
+}
+
+// splitKey separates a RESP key like "a.b.c" into its bucket path "a.b" and
+// leaf key name "c" using p's separator.
+func splitKey(p model.Path, full string) (bucketPath, key string) {
+	parts := p.Split(full)
+	if len(parts) == 1 {
+		return "", parts[0]
+	}
+	return p.Join(parts[:len(parts)-1]...), parts[len(parts)-1]
+}
+
+func (c *cli) respGet(conn redcon.Conn, cmd redcon.Command) {
+	if len(cmd.Args) != 2 {
+		conn.WriteError("ERR wrong number of arguments for 'get'")
+		return
+	}
+	bucketPath, key := splitKey(c.path, string(cmd.Args[1]))
+
+	var value []byte
+	err := c.db.View(func(tx *bolt.Tx) error {
+		b := c.path.Bucket(tx, bucketPath)
+		if b == nil {
+			return nil
+		}
+		if v := b.Get([]byte(key)); v != nil {
+			value = append([]byte(nil), v...)
+		}
+		return nil
+	})
+	if err != nil {
+		conn.WriteError(fmt.Sprintf("ERR %v", err))
+		return
+	}
+	if value == nil {
+		conn.WriteNull()
+		return
+	}
+	conn.WriteBulk(value)
+}
+
+func (c *cli) respSet(conn redcon.Conn, cmd redcon.Command) {
+	if len(cmd.Args) != 3 {
+		conn.WriteError("ERR wrong number of arguments for 'set'")
+		return
+	}
+	bucketPath, key := splitKey(c.path, string(cmd.Args[1]))
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		return putKey(tx, c.path, bucketPath, key, cmd.Args[2])
+	})
+	if err != nil {
+		conn.WriteError(fmt.Sprintf("ERR %v", err))
+		return
+	}
+	conn.WriteString("OK")
+}
+
+func (c *cli) respDel(conn redcon.Conn, cmd redcon.Command) {
+	if len(cmd.Args) != 2 {
+		conn.WriteError("ERR wrong number of arguments for 'del'")
+		return
+	}
+	bucketPath, key := splitKey(c.path, string(cmd.Args[1]))
+	deleted := 0
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		b := c.path.Bucket(tx, bucketPath)
+		if b == nil {
+			return nil
+		}
+		if b.Get([]byte(key)) != nil {
+			deleted = 1
+		}
+		return respDelKey(tx, c.path, bucketPath, key)
+	})
+	if err != nil {
+		conn.WriteError(fmt.Sprintf("ERR %v", err))
+		return
+	}
+	conn.WriteInt(deleted)
+}
+
+// respKeys implements KEYS <bucket-path>.<glob>, e.g. "a.b.*" iterates
+// bucket a -> b and globs against its key names.
+func (c *cli) respKeys(conn redcon.Conn, cmd redcon.Command) {
+	if len(cmd.Args) != 2 {
+		conn.WriteError("ERR wrong number of arguments for 'keys'")
+		return
+	}
+	bucketPath, pattern := splitKey(c.path, string(cmd.Args[1]))
+
+	var matches []string
+	err := c.db.View(func(tx *bolt.Tx) error {
+		b := c.path.Bucket(tx, bucketPath)
+		if b == nil {
+			return nil
+		}
+		for _, e := range model.Children(tx, b) {
+			if !e.IsBucket && match.Match(e.Name, pattern) {
+				matches = append(matches, e.Name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		conn.WriteError(fmt.Sprintf("ERR %v", err))
+		return
+	}
+	conn.WriteArray(len(matches))
+	for _, m := range matches {
+		conn.WriteBulkString(m)
+	}
+}
+
+// respScan implements a minimal single-pass SCAN over the bucket named by
+// the MATCH clause's prefix, always returning cursor "0" (done).
+func (c *cli) respScan(conn redcon.Conn, cmd redcon.Command) {
+	pattern := "*"
+	for i := 2; i+1 < len(cmd.Args); i += 2 {
+		if strings.ToUpper(string(cmd.Args[i])) == "MATCH" {
+			pattern = string(cmd.Args[i+1])
+		}
+	}
+	bucketPath, glob := splitKey(c.path, pattern)
+
+	var matches []string
+	err := c.db.View(func(tx *bolt.Tx) error {
+		b := c.path.Bucket(tx, bucketPath)
+		if b == nil {
+			return nil
+		}
+		for _, e := range model.Children(tx, b) {
+			if !e.IsBucket && match.Match(e.Name, glob) {
+				matches = append(matches, e.Name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		conn.WriteError(fmt.Sprintf("ERR %v", err))
+		return
+	}
+	conn.WriteArray(2)
+	conn.WriteBulkString("0")
+	conn.WriteArray(len(matches))
+	for _, m := range matches {
+		conn.WriteBulkString(m)
+	}
+}
+
+// respHGetAll dumps a bucket as a flat field/value array: HGETALL
+// <bucket-path>.
+func (c *cli) respHGetAll(conn redcon.Conn, cmd redcon.Command) {
+	if len(cmd.Args) != 2 {
+		conn.WriteError("ERR wrong number of arguments for 'hgetall'")
+		return
+	}
+	bucketPath := string(cmd.Args[1])
+
+	var fields, values []string
+	err := c.db.View(func(tx *bolt.Tx) error {
+		b := c.path.Bucket(tx, bucketPath)
+		if b == nil {
+			return fmt.Errorf("bucket %q does not exist", bucketPath)
+		}
+		for _, e := range model.Children(tx, b) {
+			if !e.IsBucket {
+				fields = append(fields, e.Name)
+				values = append(values, string(e.Value))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		conn.WriteError(fmt.Sprintf("ERR %v", err))
+		return
+	}
+	conn.WriteArray(len(fields) * 2)
+	for i := range fields {
+		conn.WriteBulkString(fields[i])
+		conn.WriteBulkString(values[i])
+	}
+}
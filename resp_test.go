@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/boltdb/bolt"
+	"github.com/schmichael/boltq/model"
+	"github.com/tidwall/redcon"
+)
+
+func redconCmd(args ...string) redcon.Command {
+	cmd := redcon.Command{}
+	for _, a := range args {
+		cmd.Args = append(cmd.Args, []byte(a))
+	}
+	return cmd
+}
+
+// TestApplyInTxGetDoesNotAbortBatch guards against a regression where
+// queuing a read command (e.g. GET) alongside writes inside MULTI/EXEC
+// caused the whole transaction, including the writes, to be rolled back.
+func TestApplyInTxGetDoesNotAbortBatch(t *testing.T) {
+	f, err := os.CreateTemp("", "boltq-resp-test-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	db, err := bolt.Open(f.Name(), 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	c := &cli{db: db, path: model.NewPath(".")}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := c.applyInTx(tx, redconCmd("SET", "kv.a", "1")); err != nil {
+			return err
+		}
+		if _, err := c.applyInTx(tx, redconCmd("GET", "kv.a")); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("applyInTx: %v", err)
+	}
+
+	err = db.View(func(tx *bolt.Tx) error {
+		b := c.path.Bucket(tx, "kv")
+		if b == nil {
+			t.Fatal("bucket kv was not created")
+		}
+		if got := string(b.Get([]byte("a"))); got != "1" {
+			t.Fatalf("kv.a = %q, want %q", got, "1")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,461 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/boltdb/bolt"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/schmichael/boltq/model"
+)
+
+// nodeRef is the payload attached to every tview.TreeNode, identifying the
+// bucket-path entry it represents.
+type nodeRef struct {
+	path     string
+	isBucket bool
+}
+
+// browser drives the interactive -tui mode: a bucket tree on the left, a
+// value/stats pane on the right, sharing all bucket-path traversal with the
+// one-shot commands via the model package. Every mutation rebuilds the tree
+// from b.root, re-expanding whatever's in b.expanded, which keeps the tree
+// state and the db in sync without incremental node surgery.
+type browser struct {
+	c     *cli
+	app   *tview.Application
+	pages *tview.Pages
+	view  *tview.TreeView
+	pane  *tview.TextView
+	root  *tview.TreeNode
+
+	filter    string          // active "/" search-in-bucket filter
+	searching bool            // true while capturing filter keystrokes
+	hex       bool            // hex/ascii toggle for binary values
+	expanded  map[string]bool // bucket paths currently expanded
+	selected  string          // path of the last-selected node, restored after reload
+}
+
+// runTUI opens a full-screen terminal browser over c.db.
+func runTUI(c *cli) error {
+	root := tview.NewTreeNode("/").SetColor(tcell.ColorYellow)
+	b := &browser{
+		c:        c,
+		view:     tview.NewTreeView().SetRoot(root).SetCurrentNode(root),
+		pane:     tview.NewTextView().SetDynamicColors(true),
+		root:     root,
+		expanded: map[string]bool{},
+	}
+	b.pane.SetBorder(true).SetTitle("value ([::b]e[::-]dit, [::b]x[::-] hex)")
+
+	if err := b.reload(); err != nil {
+		return err
+	}
+	b.view.SetChangedFunc(b.onSelect)
+	b.view.SetSelectedFunc(b.onActivate)
+	b.view.SetInputCapture(b.onKey)
+
+	flex := tview.NewFlex().
+		AddItem(b.view, 0, 1, true).
+		AddItem(b.pane, 0, 2, false)
+
+	b.pages = tview.NewPages().AddPage("main", flex, true, true)
+	b.app = tview.NewApplication().SetRoot(b.pages, true).SetFocus(b.view)
+	return b.app.Run()
+}
+
+// reload rebuilds the whole tree from the db, re-expanding every bucket
+// path in b.expanded and applying the active "/" filter to key names, then
+// restores the cursor to b.selected if it still exists.
+func (b *browser) reload() error {
+	title := b.c.db.Path()
+	if b.filter != "" {
+		title = fmt.Sprintf("%s [filter: %s]", title, b.filter)
+	}
+	b.view.SetBorder(true).SetTitle(title)
+
+	b.root.ClearChildren()
+	err := b.c.db.View(func(tx *bolt.Tx) error {
+		return b.addChildren(tx, b.root, nil, "")
+	})
+	if err != nil {
+		return err
+	}
+
+	if node := findNode(b.root, b.selected); node != nil {
+		b.view.SetCurrentNode(node)
+	}
+	return nil
+}
+
+// addChildren populates parent with the entries of bkt (or the root tx when
+// bkt is nil), filtering key names against the active search filter and
+// recursing into any bucket whose path is in b.expanded.
+func (b *browser) addChildren(tx *bolt.Tx, parent *tview.TreeNode, bkt *bolt.Bucket, path string) error {
+	for _, e := range model.Children(tx, bkt) {
+		if !e.IsBucket && b.filter != "" && !contains(e.Name, b.filter) {
+			continue
+		}
+
+		childPath := e.Name
+		if path != "" {
+			childPath = b.c.path.Join(path, e.Name)
+		}
+
+		node := tview.NewTreeNode(e.Name).SetReference(&nodeRef{path: childPath, isBucket: e.IsBucket})
+		if e.IsBucket {
+			node.SetColor(tcell.ColorGreen)
+			if b.expanded[childPath] {
+				node.SetExpanded(true)
+				var child *bolt.Bucket
+				if bkt == nil {
+					child = tx.Bucket([]byte(e.Name))
+				} else {
+					child = bkt.Bucket([]byte(e.Name))
+				}
+				if err := b.addChildren(tx, node, child, childPath); err != nil {
+					return err
+				}
+			} else {
+				node.SetExpanded(false)
+			}
+		} else {
+			node.SetColor(tcell.ColorWhite)
+		}
+		parent.AddChild(node)
+	}
+	return nil
+}
+
+// findNode walks n's subtree looking for the node referencing path.
+func findNode(n *tview.TreeNode, path string) *tview.TreeNode {
+	if path == "" {
+		return nil
+	}
+	if ref, ok := n.GetReference().(*nodeRef); ok && ref.path == path {
+		return n
+	}
+	for _, child := range n.GetChildren() {
+		if found := findNode(child, path); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// contains reports whether s contains substr, case-sensitively; split out
+// so the "/" filter has one obvious place to grow smarter matching later.
+func contains(s, substr string) bool {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if s[i:i+len(substr)] == substr {
+			return true
+		}
+	}
+	return false
+}
+
+// onSelect refreshes the value/stats pane as the cursor moves to node.
+func (b *browser) onSelect(node *tview.TreeNode) {
+	ref, _ := node.GetReference().(*nodeRef)
+	if ref == nil {
+		return
+	}
+	b.selected = ref.path
+
+	b.pane.Clear()
+	_ = b.c.db.View(func(tx *bolt.Tx) error {
+		if ref.isBucket {
+			bkt := b.c.path.Bucket(tx, ref.path)
+			if bkt == nil {
+				return nil
+			}
+			stats := bkt.Stats()
+			fmt.Fprintf(b.pane, "[yellow]%s[-] (bucket)\nKeyN: %d  Depth: %d  LeafInuse: %d\n",
+				ref.path, stats.KeyN, stats.Depth, stats.LeafInuse)
+			return nil
+		}
+
+		bucketPath, key := b.splitRef(ref.path)
+		parent := b.c.path.Bucket(tx, bucketPath)
+		if parent == nil {
+			return nil
+		}
+		fmt.Fprintf(b.pane, "[yellow]%s[-]\n%s", ref.path, b.renderValue(parent.Get([]byte(key))))
+		return nil
+	})
+}
+
+// onActivate handles Enter on the selected node: toggle expand/collapse for
+// a bucket, or open the edit form for a key.
+func (b *browser) onActivate(node *tview.TreeNode) {
+	ref, _ := node.GetReference().(*nodeRef)
+	if ref == nil {
+		return
+	}
+
+	if ref.isBucket {
+		b.expanded[ref.path] = !b.expanded[ref.path]
+		_ = b.reload()
+		return
+	}
+	b.promptEdit(ref.path)
+}
+
+// onKey handles "/" to start a search-in-bucket filter, "x" to toggle
+// hex/ascii rendering, "n"/"N" to create a key/bucket, "e" to edit the
+// selected key, and "d" to delete the selected entry.
+func (b *browser) onKey(event *tcell.EventKey) *tcell.EventKey {
+	if b.searching {
+		switch event.Key() {
+		case tcell.KeyEnter, tcell.KeyEscape:
+			b.searching = false
+			return nil
+		case tcell.KeyBackspace, tcell.KeyBackspace2:
+			if len(b.filter) > 0 {
+				b.filter = b.filter[:len(b.filter)-1]
+			}
+			_ = b.reload()
+			return nil
+		default:
+			if r := event.Rune(); r != 0 {
+				b.filter += string(r)
+				_ = b.reload()
+			}
+			return nil
+		}
+	}
+
+	switch event.Rune() {
+	case '/':
+		b.searching = true
+		b.filter = ""
+		return nil
+	case 'x':
+		b.hex = !b.hex
+		b.onSelect(b.view.GetCurrentNode())
+		return nil
+	case 'n':
+		b.promptCreateKey()
+		return nil
+	case 'N':
+		b.promptCreateBucket()
+		return nil
+	case 'e':
+		if ref := b.currentRef(); ref != nil && !ref.isBucket {
+			b.promptEdit(ref.path)
+		}
+		return nil
+	case 'd':
+		b.promptDelete()
+		return nil
+	}
+	return event
+}
+
+// currentRef returns the nodeRef of the currently focused tree node, or nil
+// at the synthetic root.
+func (b *browser) currentRef() *nodeRef {
+	node := b.view.GetCurrentNode()
+	if node == nil {
+		return nil
+	}
+	ref, _ := node.GetReference().(*nodeRef)
+	return ref
+}
+
+// currentBucketPath returns the bucket the cursor is "in": the selected
+// node itself if it's a bucket, otherwise its parent.
+func (b *browser) currentBucketPath() string {
+	ref := b.currentRef()
+	if ref == nil {
+		return ""
+	}
+	if ref.isBucket {
+		return ref.path
+	}
+	bucketPath, _ := b.splitRef(ref.path)
+	return bucketPath
+}
+
+// splitRef separates a key's full path into its bucket path and leaf key
+// name.
+func (b *browser) splitRef(path string) (bucketPath, key string) {
+	parts := b.c.path.Split(path)
+	return b.c.path.Join(parts[:len(parts)-1]...), parts[len(parts)-1]
+}
+
+// renderValue formats v as ASCII or a hex dump depending on the current
+// toggle state.
+func (b *browser) renderValue(v []byte) string {
+	if !b.hex {
+		return string(v)
+	}
+	return hex.Dump(v)
+}
+
+// showModal displays p centered over the tree/pane view and gives it focus.
+func (b *browser) showModal(p tview.Primitive) {
+	centered := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, 9, 1, true).
+			AddItem(nil, 0, 1, false), 70, 1, true).
+		AddItem(nil, 0, 1, false)
+	b.pages.AddPage("modal", centered, true, true)
+	b.app.SetFocus(p)
+}
+
+// closeModal dismisses the active modal, refreshes the tree, and returns
+// focus to it.
+func (b *browser) closeModal() {
+	b.pages.RemovePage("modal")
+	_ = b.reload()
+	b.app.SetFocus(b.view)
+}
+
+// showError replaces the active modal with a dismissable message, so a
+// failed mutation (e.g. a bucket/key name collision) is visible instead of
+// silently discarded.
+func (b *browser) showError(err error) {
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("error: %v", err)).
+		AddButtons([]string{"OK"}).
+		SetDoneFunc(func(_ int, _ string) { b.closeModal() })
+	b.pages.RemovePage("modal")
+	b.showModal(modal)
+}
+
+// promptCreateKey opens a form to put a new key/value in the currently
+// selected bucket.
+func (b *browser) promptCreateKey() {
+	bucketPath := b.currentBucketPath()
+	if bucketPath == "" {
+		return
+	}
+
+	form := tview.NewForm()
+	form.AddInputField("Key", "", 30, nil, nil)
+	form.AddInputField("Value", "", 50, nil, nil)
+	form.AddButton("Save", func() {
+		key := form.GetFormItem(0).(*tview.InputField).GetText()
+		value := form.GetFormItem(1).(*tview.InputField).GetText()
+		if key == "" {
+			b.closeModal()
+			return
+		}
+		err := b.c.db.Update(func(tx *bolt.Tx) error {
+			return putKey(tx, b.c.path, bucketPath, key, []byte(value))
+		})
+		if err != nil {
+			b.showError(err)
+			return
+		}
+		b.expanded[bucketPath] = true
+		b.closeModal()
+	})
+	form.AddButton("Cancel", func() { b.closeModal() })
+	form.SetBorder(true).SetTitle(fmt.Sprintf("New key in %s", bucketPath))
+	b.showModal(form)
+}
+
+// promptCreateBucket opens a form to create a bucket nested under the
+// currently selected bucket (or at the top level, if nothing is selected).
+func (b *browser) promptCreateBucket() {
+	parentPath := b.currentBucketPath()
+
+	form := tview.NewForm()
+	form.AddInputField("Bucket name", "", 30, nil, nil)
+	form.AddButton("Create", func() {
+		name := form.GetFormItem(0).(*tview.InputField).GetText()
+		if name == "" {
+			b.closeModal()
+			return
+		}
+		full := name
+		if parentPath != "" {
+			full = b.c.path.Join(parentPath, name)
+		}
+		err := b.c.db.Update(func(tx *bolt.Tx) error {
+			_, err := b.c.path.CreateBucket(tx, full)
+			return err
+		})
+		if err != nil {
+			b.showError(err)
+			return
+		}
+		b.expanded[parentPath] = true
+		b.closeModal()
+	})
+	form.AddButton("Cancel", func() { b.closeModal() })
+	form.SetBorder(true).SetTitle("New bucket")
+	b.showModal(form)
+}
+
+// promptEdit opens a form to overwrite the value stored at keyPath.
+func (b *browser) promptEdit(keyPath string) {
+	bucketPath, key := b.splitRef(keyPath)
+
+	var current []byte
+	_ = b.c.db.View(func(tx *bolt.Tx) error {
+		if bkt := b.c.path.Bucket(tx, bucketPath); bkt != nil {
+			current = append([]byte(nil), bkt.Get([]byte(key))...)
+		}
+		return nil
+	})
+
+	form := tview.NewForm()
+	form.AddInputField("Value", string(current), 50, nil, nil)
+	form.AddButton("Save", func() {
+		newValue := form.GetFormItem(0).(*tview.InputField).GetText()
+		err := b.c.db.Update(func(tx *bolt.Tx) error {
+			return putKey(tx, b.c.path, bucketPath, key, []byte(newValue))
+		})
+		if err != nil {
+			b.showError(err)
+			return
+		}
+		b.closeModal()
+	})
+	form.AddButton("Cancel", func() { b.closeModal() })
+	form.SetBorder(true).SetTitle(fmt.Sprintf("Edit %s", keyPath))
+	b.showModal(form)
+}
+
+// promptDelete asks for confirmation, then deletes the currently selected
+// key or bucket.
+func (b *browser) promptDelete() {
+	ref := b.currentRef()
+	if ref == nil || ref.path == "" {
+		return
+	}
+
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("Delete %s?", ref.path)).
+		AddButtons([]string{"Delete", "Cancel"}).
+		SetDoneFunc(func(_ int, label string) {
+			if label != "Delete" {
+				b.closeModal()
+				return
+			}
+			err := b.c.db.Update(func(tx *bolt.Tx) error {
+				if ref.isBucket {
+					return b.c.path.DeleteBucket(tx, ref.path)
+				}
+				bucketPath, key := b.splitRef(ref.path)
+				return deleteKey(tx, b.c.path, bucketPath, key)
+			})
+			if err != nil {
+				b.showError(err)
+				return
+			}
+			if ref.isBucket {
+				delete(b.expanded, ref.path)
+			}
+			b.selected = ""
+			b.closeModal()
+		})
+	b.showModal(modal)
+}